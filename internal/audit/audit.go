@@ -0,0 +1,144 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package audit builds redacted before/after diffs for the cross-cutting
+// audit trail: who changed what and when, across repos.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// redactedFields lists the JSON field names (case-insensitive, at any
+// nesting depth) whose values are replaced before a diff is persisted.
+var redactedFields = map[string]bool{
+	"password": true,
+	"apikey":   true,
+	"api_key":  true,
+}
+
+const redactedPlaceholder = "[REDACTED]"
+
+// change is the shape persisted in audit_log.diff.
+type change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Diff marshals before and after to JSON, redacts sensitive fields, and
+// returns the combined envelope ready to store in audit_log.diff. Either
+// side may be nil, e.g. before is nil for a create and after is nil for a
+// delete.
+func Diff(before, after any) (json.RawMessage, error) {
+	b, err := redactedJSON(before)
+	if err != nil {
+		return nil, errors.Wrap(err, "error redacting before value")
+	}
+
+	a, err := redactedJSON(after)
+	if err != nil {
+		return nil, errors.Wrap(err, "error redacting after value")
+	}
+
+	// Assigned individually, rather than via a Before: b literal, so a nil
+	// map doesn't get boxed into the any field: encoding/json's omitempty
+	// only elides an interface field that is itself nil, and a typed-nil
+	// map boxed into an any is not considered nil by that check.
+	var c change
+	if b != nil {
+		c.Before = b
+	}
+	if a != nil {
+		c.After = a
+	}
+
+	out, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling audit diff")
+	}
+
+	return out, nil
+}
+
+func redactedJSON(v any) (map[string]any, error) {
+	if isNil(v) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	redact(m)
+
+	return m, nil
+}
+
+// isNil reports whether v is untyped nil, or a typed nil pointer/interface/
+// map/slice/chan/func. Callers like recordAudit pass a typed *domain.DownloadClient
+// that's nil on create/delete, which a bare `v == nil` check on the any
+// doesn't catch since the interface itself is non-nil.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+func redact(m map[string]any) {
+	for k, v := range m {
+		if redactedFields[strings.ToLower(k)] {
+			m[k] = redactedPlaceholder
+			continue
+		}
+
+		redactValue(v)
+	}
+}
+
+// redactValue redacts v in place if it is a JSON object, or walks into it if
+// it is an array, so objects nested inside arrays are redacted too.
+func redactValue(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		redact(t)
+	case []any:
+		for _, e := range t {
+			redactValue(e)
+		}
+	}
+}
+
+type actorIDKey struct{}
+
+// ContextWithActorID attaches the acting user's ID to ctx, so repos can
+// record who performed a mutation without threading it through every call
+// signature.
+func ContextWithActorID(ctx context.Context, actorID int) context.Context {
+	return context.WithValue(ctx, actorIDKey{}, actorID)
+}
+
+// ActorIDFromContext returns the actor ID attached by ContextWithActorID, or
+// 0 if the context carries none (e.g. a system-initiated change).
+func ActorIDFromContext(ctx context.Context) int {
+	actorID, _ := ctx.Value(actorIDKey{}).(int)
+	return actorID
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package crypto seals and unseals secrets (download client passwords, API
+// keys, and the like) before they are persisted, so a stolen database file
+// or backup does not hand over live credentials.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// envelopePrefix marks a value as a sealed envelope. It includes a NUL byte,
+// which can't occur in a value typed into a web form or config file, so a
+// legacy plaintext credential can never be mistaken for one (a plain "v1:"
+// string prefix could, e.g. a password that happens to start with a version
+// string) and end up hard-failing decryption in Unseal.
+const envelopePrefix = "\x00autobrr:sealed:v1:"
+
+// Sealer seals and unseals plaintext secrets using an AEAD cipher. A zero
+// value Sealer is not usable; construct one with New.
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// New derives a 256-bit AES-GCM key from the given master key material
+// (loaded by the caller from config or an OS keychain) and returns a Sealer.
+func New(masterKey []byte) (*Sealer, error) {
+	if len(masterKey) == 0 {
+		return nil, errors.New("crypto: master key must not be empty")
+	}
+
+	key := sha256.Sum256(masterKey)
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating aes cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating gcm aead")
+	}
+
+	return &Sealer{aead: aead}, nil
+}
+
+// Seal encrypts plaintext and returns an envelope of the form
+// "<envelopePrefix><nonce>:<ciphertext>", both parts base64 encoded. An
+// empty plaintext is returned unchanged so optional fields don't round-trip
+// as ciphertext.
+func (s *Sealer) Seal(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "error generating nonce")
+	}
+
+	ciphertext := s.aead.Seal(nil, nonce, []byte(plaintext), nil)
+
+	return envelopePrefix + strings.Join([]string{
+		base64.StdEncoding.EncodeToString(nonce),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// Unseal reverses Seal. A plain, unencrypted value (e.g. from a database
+// predating this package, or an empty field) is returned as-is so existing
+// rows keep working until they're rewritten by Store/Update.
+func (s *Sealer) Unseal(sealed string) (string, error) {
+	if sealed == "" || !IsSealed(sealed) {
+		return sealed, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(sealed, envelopePrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", errors.New("crypto: malformed envelope")
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.Wrap(err, "error decoding nonce")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(err, "error decoding ciphertext")
+	}
+
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "error decrypting value")
+	}
+
+	return string(plaintext), nil
+}
+
+// IsSealed reports whether value looks like an envelope produced by Seal, as
+// opposed to a plaintext legacy value. envelopePrefix contains a NUL byte
+// that can't come from a credential typed by a user, so this can't
+// misclassify a plaintext value and send it into Unseal's AEAD path.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// RotateKey unseals sealed with the old Sealer and reseals the resulting
+// plaintext with the new one, so stored envelopes can be migrated to a new
+// master key without ever persisting the plaintext in between.
+func RotateKey(old, new *Sealer, sealed string) (string, error) {
+	plaintext, err := old.Unseal(sealed)
+	if err != nil {
+		return "", errors.Wrap(err, "error unsealing with old key")
+	}
+
+	resealed, err := new.Seal(plaintext)
+	if err != nil {
+		return "", errors.Wrap(err, "error sealing with new key")
+	}
+
+	return resealed, nil
+}
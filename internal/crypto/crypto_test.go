@@ -0,0 +1,112 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package crypto
+
+import "testing"
+
+func TestSealUnsealRoundtrip(t *testing.T) {
+	s, err := New([]byte("test-master-key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sealed, err := s.Seal("hunter2")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	if sealed == "hunter2" {
+		t.Fatalf("Seal() returned plaintext unchanged")
+	}
+
+	if !IsSealed(sealed) {
+		t.Fatalf("IsSealed(%q) = false, want true", sealed)
+	}
+
+	got, err := s.Unseal(sealed)
+	if err != nil {
+		t.Fatalf("Unseal() error = %v", err)
+	}
+	if got != "hunter2" {
+		t.Fatalf("Unseal() = %q, want %q", got, "hunter2")
+	}
+}
+
+func TestSealEmptyPlaintext(t *testing.T) {
+	s, err := New([]byte("test-master-key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sealed, err := s.Seal("")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if sealed != "" {
+		t.Fatalf("Seal(\"\") = %q, want empty string", sealed)
+	}
+}
+
+func TestUnsealLegacyPlaintextPassthrough(t *testing.T) {
+	s, err := New([]byte("test-master-key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// A legacy plaintext credential that happens to start with the old
+	// bare "v1:" prefix must not be mistaken for a sealed envelope.
+	legacy := "v1:not-actually-encrypted"
+
+	if IsSealed(legacy) {
+		t.Fatalf("IsSealed(%q) = true, want false", legacy)
+	}
+
+	got, err := s.Unseal(legacy)
+	if err != nil {
+		t.Fatalf("Unseal() error = %v", err)
+	}
+	if got != legacy {
+		t.Fatalf("Unseal(%q) = %q, want unchanged", legacy, got)
+	}
+}
+
+func TestIsSealedEmptyValue(t *testing.T) {
+	if IsSealed("") {
+		t.Fatalf("IsSealed(\"\") = true, want false")
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	oldSealer, err := New([]byte("old-master-key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	newSealer, err := New([]byte("new-master-key"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sealed, err := oldSealer.Seal("hunter2")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	rotated, err := RotateKey(oldSealer, newSealer, sealed)
+	if err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	if _, err := oldSealer.Unseal(rotated); err == nil {
+		t.Fatalf("Unseal() with old sealer succeeded on key-rotated value, want error")
+	}
+
+	got, err := newSealer.Unseal(rotated)
+	if err != nil {
+		t.Fatalf("Unseal() with new sealer error = %v", err)
+	}
+	if got != "hunter2" {
+		t.Fatalf("Unseal() = %q, want %q", got, "hunter2")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+
+	"github.com/autobrr/autobrr/internal/audit"
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/errors"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const entityTypeDownloadClient = "download_client"
+
+// recordAudit writes an audit_log row inside tx so the audit trail commits
+// atomically with the mutation it describes, or not at all. before/after may
+// be nil; Diff redacts password/apikey fields regardless.
+func (r *DownloadClientRepo) recordAudit(ctx context.Context, tx *Tx, action domain.AuditAction, entityID int, before, after *domain.DownloadClient) error {
+	diff, err := audit.Diff(before, after)
+	if err != nil {
+		return errors.Wrap(err, "error building audit diff")
+	}
+
+	queryBuilder := r.db.squirrel.
+		Insert("audit_log").
+		Columns("actor_user_id", "entity_type", "entity_id", "action", "diff").
+		Values(audit.ActorIDFromContext(ctx), entityTypeDownloadClient, entityID, action, string(diff))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	return nil
+}
+
+func (r *DownloadClientRepo) ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, int, error) {
+	where := sq.Eq{"entity_type": entityTypeDownloadClient}
+	if filter.EntityID != 0 {
+		where["entity_id"] = filter.EntityID
+	}
+	if filter.ActorUserID != 0 {
+		where["actor_user_id"] = filter.ActorUserID
+	}
+
+	countBuilder := r.db.squirrel.Select("COUNT(*)").From("audit_log").Where(where)
+	if !filter.From.IsZero() {
+		countBuilder = countBuilder.Where(sq.GtOrEq{"created_at": filter.From})
+	}
+	if !filter.To.IsZero() {
+		countBuilder = countBuilder.Where(sq.LtOrEq{"created_at": filter.To})
+	}
+
+	countQuery, countArgs, err := countBuilder.ToSql()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error building query")
+	}
+
+	var total int
+	if err := r.db.handler.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, errors.Wrap(err, "error executing query")
+	}
+
+	queryBuilder := r.db.squirrel.
+		Select("id", "actor_user_id", "entity_type", "entity_id", "action", "diff", "created_at").
+		From("audit_log").
+		Where(where).
+		OrderBy("created_at DESC")
+
+	if !filter.From.IsZero() {
+		queryBuilder = queryBuilder.Where(sq.GtOrEq{"created_at": filter.From})
+	}
+	if !filter.To.IsZero() {
+		queryBuilder = queryBuilder.Where(sq.LtOrEq{"created_at": filter.To})
+	}
+	if filter.Limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		queryBuilder = queryBuilder.Offset(uint64(filter.Offset))
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error building query")
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error executing query")
+	}
+	defer rows.Close()
+
+	entries := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var e domain.AuditEntry
+		if err := rows.Scan(&e.ID, &e.ActorUserID, &e.EntityType, &e.EntityID, &e.Action, &e.Diff, &e.CreatedAt); err != nil {
+			return entries, total, errors.Wrap(err, "error scanning row")
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return entries, total, errors.Wrap(err, "rows error")
+	}
+
+	return entries, total, nil
+}
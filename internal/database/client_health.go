@@ -0,0 +1,117 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Minute
+	defaultHealthCheckTimeout  = 10 * time.Second
+)
+
+func (r *DownloadClientRepo) GetHealth(id int) *domain.ClientHealth {
+	return r.cache.GetHealth(id)
+}
+
+func (r *DownloadClientRepo) ListWithHealth(ctx context.Context) ([]domain.DownloadClientWithHealth, error) {
+	clients, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	withHealth := make([]domain.DownloadClientWithHealth, 0, len(clients))
+	for _, client := range clients {
+		withHealth = append(withHealth, domain.DownloadClientWithHealth{
+			DownloadClient: client,
+			Health:         r.cache.GetHealth(client.ID),
+		})
+	}
+
+	return withHealth, nil
+}
+
+// probeAndCache probes client immediately and caches the result. It's called
+// right after Store/Update so a newly added or edited client's health is
+// known before the next scheduled probe, rather than showing as unknown.
+func (r *DownloadClientRepo) probeAndCache(ctx context.Context, client domain.DownloadClient) {
+	if r.prober == nil {
+		return
+	}
+
+	timeout := defaultHealthCheckTimeout
+	if client.HealthCheckTimeout > 0 {
+		timeout = time.Duration(client.HealthCheckTimeout) * time.Second
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	health, err := r.prober.Probe(probeCtx, client)
+	if err != nil {
+		health = domain.ClientHealth{
+			ClientID:  client.ID,
+			Reachable: false,
+			Error:     err.Error(),
+			CheckedAt: time.Now(),
+		}
+	}
+	health.ClientID = client.ID
+
+	r.cache.SetHealth(client.ID, &health)
+}
+
+// StartHealthMonitor runs until ctx is cancelled, periodically probing every
+// stored download client and caching the result so the web UI and action
+// executor can short-circuit sends to known-down clients instead of failing
+// at push time. Each client is probed on its own configured interval.
+//
+// Due clients are probed from their own goroutine rather than serially in
+// the ticker handler: probeAndCache can block for up to the client's
+// configured timeout, and a single slow client must not delay every other
+// due client's probe for that tick (time.Ticker drops ticks it can't
+// deliver, so a blocked handler silently skips ticks entirely).
+func (r *DownloadClientRepo) StartHealthMonitor(ctx context.Context) {
+	if r.prober == nil {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	lastProbed := make(map[int]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			clients, err := r.List(ctx)
+			if err != nil {
+				r.log.Error().Err(err).Msg("health monitor: error listing download clients")
+				continue
+			}
+
+			for _, client := range clients {
+				interval := defaultHealthCheckInterval
+				if client.HealthCheckInterval > 0 {
+					interval = time.Duration(client.HealthCheckInterval) * time.Second
+				}
+
+				if due, ok := lastProbed[client.ID]; ok && now.Sub(due) < interval {
+					continue
+				}
+
+				lastProbed[client.ID] = now
+
+				client := client
+				go r.probeAndCache(ctx, client)
+			}
+		}
+	}
+}
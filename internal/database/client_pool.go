@@ -0,0 +1,279 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+
+	"github.com/autobrr/autobrr/internal/domain"
+	"github.com/autobrr/autobrr/pkg/errors"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func (r *DownloadClientRepo) ListPools(ctx context.Context) ([]domain.ClientPool, error) {
+	pools := make([]domain.ClientPool, 0)
+
+	queryBuilder := r.db.squirrel.
+		Select("id", "name", "strategy").
+		From("client_pool")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building query")
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error executing query")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.ClientPool
+		if err := rows.Scan(&p.ID, &p.Name, &p.Strategy); err != nil {
+			return pools, errors.Wrap(err, "error scanning row")
+		}
+
+		members, err := r.listPoolMembers(ctx, p.ID)
+		if err != nil {
+			return pools, err
+		}
+		p.Members = members
+
+		pools = append(pools, p)
+	}
+	if err := rows.Err(); err != nil {
+		return pools, errors.Wrap(err, "rows error")
+	}
+
+	return pools, nil
+}
+
+func (r *DownloadClientRepo) FindPoolByID(ctx context.Context, id int) (*domain.ClientPool, error) {
+	queryBuilder := r.db.squirrel.
+		Select("id", "name", "strategy").
+		From("client_pool").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building query")
+	}
+
+	row := r.db.handler.QueryRowContext(ctx, query, args...)
+
+	var pool domain.ClientPool
+	if err := row.Scan(&pool.ID, &pool.Name, &pool.Strategy); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("no client pool configured")
+		}
+		return nil, errors.Wrap(err, "error scanning row")
+	}
+
+	members, err := r.listPoolMembers(ctx, pool.ID)
+	if err != nil {
+		return nil, err
+	}
+	pool.Members = members
+
+	return &pool, nil
+}
+
+func (r *DownloadClientRepo) listPoolMembers(ctx context.Context, poolID int) ([]domain.ClientPoolMember, error) {
+	members := make([]domain.ClientPoolMember, 0)
+
+	queryBuilder := r.db.squirrel.
+		Select("pool_id", "client_id", "priority").
+		From("client_pool_member").
+		Where(sq.Eq{"pool_id": poolID}).
+		OrderBy("priority ASC")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building query")
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error executing query")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m domain.ClientPoolMember
+		if err := rows.Scan(&m.PoolID, &m.ClientID, &m.Priority); err != nil {
+			return members, errors.Wrap(err, "error scanning row")
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *DownloadClientRepo) StorePool(ctx context.Context, pool domain.ClientPool) (*domain.ClientPool, error) {
+	queryBuilder := r.db.squirrel.
+		Insert("client_pool").
+		Columns("name", "strategy").
+		Values(pool.Name, pool.Strategy).
+		Suffix("RETURNING id").RunWith(r.db.handler)
+
+	var retID int
+	if err := queryBuilder.QueryRowContext(ctx).Scan(&retID); err != nil {
+		return nil, errors.Wrap(err, "error executing query")
+	}
+
+	pool.ID = retID
+
+	r.log.Debug().Msgf("client_pool.store: %d", pool.ID)
+
+	return &pool, nil
+}
+
+func (r *DownloadClientRepo) AddMember(ctx context.Context, poolID, clientID, priority int) error {
+	queryBuilder := r.db.squirrel.
+		Insert("client_pool_member").
+		Columns("pool_id", "client_id", "priority").
+		Values(poolID, clientID, priority)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	if _, err := r.db.handler.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	r.log.Debug().Msgf("client_pool.add_member: pool %d client %d priority %d", poolID, clientID, priority)
+
+	return nil
+}
+
+func (r *DownloadClientRepo) RemoveMember(ctx context.Context, poolID, clientID int) error {
+	queryBuilder := r.db.squirrel.
+		Delete("client_pool_member").
+		Where(sq.Eq{"pool_id": poolID, "client_id": clientID})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	if _, err := r.db.handler.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	r.log.Debug().Msgf("client_pool.remove_member: pool %d client %d", poolID, clientID)
+
+	return nil
+}
+
+func (r *DownloadClientRepo) DeletePool(ctx context.Context, poolID int) error {
+	queryBuilder := r.db.squirrel.
+		Delete("client_pool").
+		Where(sq.Eq{"id": poolID})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	if _, err := r.db.handler.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	r.log.Debug().Msgf("client_pool.delete: %d", poolID)
+
+	return nil
+}
+
+// poolRoundRobin tracks the next member index per pool for the ROUND_ROBIN
+// strategy. It is intentionally process-local: a restart simply resets the
+// rotation rather than requiring a persisted cursor.
+var poolRoundRobin sync.Map
+
+// PickMember resolves a live download client for the given pool according to
+// its configured strategy, skipping members whose last health probe came
+// back unreachable. Members are considered in priority order.
+func (r *DownloadClientRepo) PickMember(ctx context.Context, poolID int) (*domain.DownloadClient, error) {
+	pool, err := r.FindPoolByID(ctx, poolID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding client pool: %d", poolID)
+	}
+
+	if len(pool.Members) == 0 {
+		return nil, errors.New("client pool %d has no members", poolID)
+	}
+
+	candidates := make([]*domain.DownloadClient, 0, len(pool.Members))
+	for _, m := range pool.Members {
+		client, err := r.FindByID(ctx, int32(m.ClientID))
+		if err != nil {
+			r.log.Debug().Err(err).Msgf("client_pool: skipping member %d in pool %d, lookup failed", m.ClientID, poolID)
+			continue
+		}
+
+		if health := r.cache.GetHealth(m.ClientID); health != nil && !health.Reachable {
+			r.log.Debug().Msgf("client_pool: skipping unhealthy member %d in pool %d: %s", m.ClientID, poolID, health.Error)
+			continue
+		}
+
+		candidates = append(candidates, client)
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.New("client pool %d has no healthy members", poolID)
+	}
+
+	switch pool.Strategy {
+	case domain.ClientPoolStrategyFirstHealthy:
+		return candidates[0], nil
+	case domain.ClientPoolStrategyRoundRobin:
+		v, _ := poolRoundRobin.LoadOrStore(poolID, new(uint64))
+		counter := v.(*uint64)
+		idx := atomic.AddUint64(counter, 1) - 1
+		return candidates[idx%uint64(len(candidates))], nil
+	case domain.ClientPoolStrategyLeastActive:
+		return r.pickLeastActive(ctx, poolID, candidates)
+	default:
+		return candidates[0], nil
+	}
+}
+
+// pickLeastActive queries each candidate's native API for its current active
+// torrent count and returns the lightest loaded one. A candidate that fails
+// to respond is skipped rather than preferred or treated as a hard failure.
+func (r *DownloadClientRepo) pickLeastActive(ctx context.Context, poolID int, candidates []*domain.DownloadClient) (*domain.DownloadClient, error) {
+	if r.prober == nil {
+		return candidates[0], nil
+	}
+
+	var best *domain.DownloadClient
+	bestActive := -1
+
+	for _, client := range candidates {
+		active, err := r.prober.ActiveTorrents(ctx, *client)
+		if err != nil {
+			r.log.Debug().Err(err).Msgf("client_pool: error querying active torrents for member %d in pool %d", client.ID, poolID)
+			continue
+		}
+
+		if best == nil || active < bestActive {
+			best = client
+			bestActive = active
+		}
+	}
+
+	if best == nil {
+		// every candidate failed to respond to the active-torrents query;
+		// fall back to the first one rather than failing the push outright.
+		return candidates[0], nil
+	}
+
+	return best, nil
+}
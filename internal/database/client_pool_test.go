@@ -0,0 +1,94 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/rs/zerolog"
+)
+
+type fakeProber struct {
+	active map[int]int
+	errs   map[int]error
+}
+
+func (f fakeProber) Probe(ctx context.Context, client domain.DownloadClient) (domain.ClientHealth, error) {
+	return domain.ClientHealth{ClientID: client.ID, Reachable: true}, nil
+}
+
+func (f fakeProber) ActiveTorrents(ctx context.Context, client domain.DownloadClient) (int, error) {
+	if err, ok := f.errs[client.ID]; ok {
+		return 0, err
+	}
+	return f.active[client.ID], nil
+}
+
+func newTestDownloadClientRepo(prober domain.ClientProber) *DownloadClientRepo {
+	return &DownloadClientRepo{
+		log:    zerolog.Nop(),
+		cache:  NewClientCache(),
+		prober: prober,
+	}
+}
+
+func TestPickLeastActivePrefersLowestActiveCount(t *testing.T) {
+	r := newTestDownloadClientRepo(fakeProber{active: map[int]int{1: 5, 2: 1, 3: 3}})
+
+	candidates := []*domain.DownloadClient{
+		{ID: 1}, {ID: 2}, {ID: 3},
+	}
+
+	got, err := r.pickLeastActive(context.Background(), 1, candidates)
+	if err != nil {
+		t.Fatalf("pickLeastActive() error = %v", err)
+	}
+	if got.ID != 2 {
+		t.Fatalf("pickLeastActive() = client %d, want client 2", got.ID)
+	}
+}
+
+func TestPickLeastActiveSkipsUnresponsiveCandidates(t *testing.T) {
+	r := newTestDownloadClientRepo(fakeProber{
+		active: map[int]int{2: 4},
+		errs:   map[int]error{1: errTestProbeFailed},
+	})
+
+	candidates := []*domain.DownloadClient{
+		{ID: 1}, {ID: 2},
+	}
+
+	got, err := r.pickLeastActive(context.Background(), 1, candidates)
+	if err != nil {
+		t.Fatalf("pickLeastActive() error = %v", err)
+	}
+	if got.ID != 2 {
+		t.Fatalf("pickLeastActive() = client %d, want client 2", got.ID)
+	}
+}
+
+func TestPickLeastActiveFallsBackWhenNoProber(t *testing.T) {
+	r := newTestDownloadClientRepo(nil)
+
+	candidates := []*domain.DownloadClient{
+		{ID: 1}, {ID: 2},
+	}
+
+	got, err := r.pickLeastActive(context.Background(), 1, candidates)
+	if err != nil {
+		t.Fatalf("pickLeastActive() error = %v", err)
+	}
+	if got.ID != 1 {
+		t.Fatalf("pickLeastActive() = client %d, want client 1 (first candidate fallback)", got.ID)
+	}
+}
+
+type testProbeError struct{}
+
+func (testProbeError) Error() string { return "probe failed" }
+
+var errTestProbeFailed = testProbeError{}
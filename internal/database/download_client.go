@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"sync"
 
+	"github.com/autobrr/autobrr/internal/crypto"
 	"github.com/autobrr/autobrr/internal/domain"
 	"github.com/autobrr/autobrr/internal/logger"
 	"github.com/autobrr/autobrr/pkg/errors"
@@ -18,19 +19,24 @@ import (
 )
 
 type DownloadClientRepo struct {
-	log   zerolog.Logger
-	db    *DB
-	cache *clientCache
+	log      zerolog.Logger
+	db       *DB
+	cache    *clientCache
+	sealerMu sync.RWMutex
+	sealer   *crypto.Sealer
+	prober   domain.ClientProber
 }
 
 type clientCache struct {
 	mu      sync.RWMutex
 	clients map[int]*domain.DownloadClient
+	health  map[int]*domain.ClientHealth
 }
 
 func NewClientCache() *clientCache {
 	return &clientCache{
 		clients: make(map[int]*domain.DownloadClient, 0),
+		health:  make(map[int]*domain.ClientHealth, 0),
 	}
 }
 
@@ -53,15 +59,99 @@ func (c *clientCache) Get(id int) *domain.DownloadClient {
 func (c *clientCache) Pop(id int) {
 	c.mu.Lock()
 	delete(c.clients, id)
+	delete(c.health, id)
 	c.mu.Unlock()
 }
 
-func NewDownloadClientRepo(log logger.Logger, db *DB) domain.DownloadClientRepo {
-	return &DownloadClientRepo{
-		log:   log.With().Str("repo", "action").Logger(),
-		db:    db,
-		cache: NewClientCache(),
+func (c *clientCache) SetHealth(id int, health *domain.ClientHealth) {
+	c.mu.Lock()
+	c.health[id] = health
+	c.mu.Unlock()
+}
+
+func (c *clientCache) GetHealth(id int) *domain.ClientHealth {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.health[id]
+}
+
+func NewDownloadClientRepo(log logger.Logger, db *DB, sealer *crypto.Sealer, prober domain.ClientProber) domain.DownloadClientRepo {
+	r := &DownloadClientRepo{
+		log:    log.With().Str("repo", "action").Logger(),
+		db:     db,
+		cache:  NewClientCache(),
+		sealer: sealer,
+		prober: prober,
+	}
+
+	if sealer != nil {
+		if err := r.reencryptLegacyRows(context.Background()); err != nil {
+			r.log.Error().Err(err).Msg("error re-encrypting legacy download client credentials")
+		}
 	}
+
+	return r
+}
+
+// reencryptLegacyRows runs once at startup when encryption at rest is
+// enabled: it rewrites any row still holding plaintext credentials (i.e.
+// predating this sealer) through Update, which always seals on write.
+// Rows already sealed are left untouched; use RotateKey to re-encrypt those
+// under a new master key instead.
+func (r *DownloadClientRepo) reencryptLegacyRows(ctx context.Context) error {
+	query, args, err := r.db.squirrel.Select("id", "username", "password", "settings").From("client").ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	// Seal leaves an empty value empty, so only a non-empty unsealed value is plaintext.
+	isPlaintext := func(v string) bool { return v != "" && !crypto.IsSealed(v) }
+
+	var legacyIDs []int32
+	for rows.Next() {
+		var id int32
+		var username, password, settingsJsonStr string
+		if err := rows.Scan(&id, &username, &password, &settingsJsonStr); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "error scanning row")
+		}
+		var settings domain.DownloadClientSettings
+		if settingsJsonStr != "" {
+			if err := json.Unmarshal([]byte(settingsJsonStr), &settings); err != nil {
+				rows.Close()
+				return errors.Wrap(err, "could not unmarshal download client settings: %d", id)
+			}
+		}
+		if isPlaintext(password) || isPlaintext(username) || isPlaintext(settings.APIKey) {
+			legacyIDs = append(legacyIDs, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return errors.Wrap(err, "rows error")
+	}
+	rows.Close()
+
+	for _, id := range legacyIDs {
+		client, err := r.FindByID(ctx, id)
+		if err != nil {
+			return errors.Wrap(err, "error finding download client: %d", id)
+		}
+		if _, err := r.Update(ctx, *client); err != nil {
+			return errors.Wrap(err, "error re-encrypting download client: %d", id)
+		}
+	}
+
+	if len(legacyIDs) > 0 {
+		r.log.Info().Msgf("re-encrypted %d download clients on first boot", len(legacyIDs))
+	}
+
+	return nil
 }
 
 func (r *DownloadClientRepo) List(ctx context.Context) ([]domain.DownloadClient, error) {
@@ -80,6 +170,8 @@ func (r *DownloadClientRepo) List(ctx context.Context) ([]domain.DownloadClient,
 			"username",
 			"password",
 			"settings",
+			"health_check_interval",
+			"health_check_timeout",
 		).
 		From("client")
 
@@ -99,7 +191,7 @@ func (r *DownloadClientRepo) List(ctx context.Context) ([]domain.DownloadClient,
 		var f domain.DownloadClient
 		var settingsJsonStr string
 
-		if err := rows.Scan(&f.ID, &f.Name, &f.Type, &f.Enabled, &f.Host, &f.Port, &f.TLS, &f.TLSSkipVerify, &f.Username, &f.Password, &settingsJsonStr); err != nil {
+		if err := rows.Scan(&f.ID, &f.Name, &f.Type, &f.Enabled, &f.Host, &f.Port, &f.TLS, &f.TLSSkipVerify, &f.Username, &f.Password, &settingsJsonStr, &f.HealthCheckInterval, &f.HealthCheckTimeout); err != nil {
 			return clients, errors.Wrap(err, "error scanning row")
 		}
 
@@ -109,6 +201,10 @@ func (r *DownloadClientRepo) List(ctx context.Context) ([]domain.DownloadClient,
 			}
 		}
 
+		if err := r.unsealClient(&f); err != nil {
+			return clients, errors.Wrap(err, "error decrypting download client: %d", f.ID)
+		}
+
 		clients = append(clients, f)
 	}
 	if err := rows.Err(); err != nil {
@@ -125,6 +221,13 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 		return c, nil
 	}
 
+	return r.findByIDFromDB(ctx, id)
+}
+
+// findByIDFromDB always hits the database, bypassing the cache. Use this
+// (not FindByID) wherever a caller needs the row as it actually exists right
+// now, e.g. building an audit "before" snapshot.
+func (r *DownloadClientRepo) findByIDFromDB(ctx context.Context, id int32) (*domain.DownloadClient, error) {
 	queryBuilder := r.db.squirrel.
 		Select(
 			"id",
@@ -138,6 +241,8 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 			"username",
 			"password",
 			"settings",
+			"health_check_interval",
+			"health_check_timeout",
 		).
 		From("client").
 		Where(sq.Eq{"id": id})
@@ -148,14 +253,11 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 	}
 
 	row := r.db.handler.QueryRowContext(ctx, query, args...)
-	if err != nil {
-		return nil, errors.Wrap(err, "error executing query")
-	}
 
 	var client domain.DownloadClient
 	var settingsJsonStr string
 
-	if err := row.Scan(&client.ID, &client.Name, &client.Type, &client.Enabled, &client.Host, &client.Port, &client.TLS, &client.TLSSkipVerify, &client.Username, &client.Password, &settingsJsonStr); err != nil {
+	if err := row.Scan(&client.ID, &client.Name, &client.Type, &client.Enabled, &client.Host, &client.Port, &client.TLS, &client.TLSSkipVerify, &client.Username, &client.Password, &settingsJsonStr, &client.HealthCheckInterval, &client.HealthCheckTimeout); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("no client configured")
 		}
@@ -169,14 +271,23 @@ func (r *DownloadClientRepo) FindByID(ctx context.Context, id int32) (*domain.Do
 		}
 	}
 
+	if err := r.unsealClient(&client); err != nil {
+		return nil, errors.Wrap(err, "error decrypting download client: %d", client.ID)
+	}
+
 	return &client, nil
 }
 
 func (r *DownloadClientRepo) Store(ctx context.Context, client domain.DownloadClient) (*domain.DownloadClient, error) {
 	var err error
 
+	sealedUsername, sealedPassword, sealedAPIKey, err := r.sealCredentials(client.Username, client.Password, client.Settings.APIKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encrypting download client credentials")
+	}
+
 	settings := domain.DownloadClientSettings{
-		APIKey: client.Settings.APIKey,
+		APIKey: sealedAPIKey,
 		Basic:  client.Settings.Basic,
 		Rules:  client.Settings.Rules,
 	}
@@ -186,11 +297,17 @@ func (r *DownloadClientRepo) Store(ctx context.Context, client domain.DownloadCl
 		return nil, errors.Wrap(err, "error marshal download client settings %+v", settings)
 	}
 
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelWriteCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	queryBuilder := r.db.squirrel.
 		Insert("client").
-		Columns("name", "type", "enabled", "host", "port", "tls", "tls_skip_verify", "username", "password", "settings").
-		Values(client.Name, client.Type, client.Enabled, client.Host, client.Port, client.TLS, client.TLSSkipVerify, client.Username, client.Password, settingsJson).
-		Suffix("RETURNING id").RunWith(r.db.handler)
+		Columns("name", "type", "enabled", "host", "port", "tls", "tls_skip_verify", "username", "password", "settings", "health_check_interval", "health_check_timeout").
+		Values(client.Name, client.Type, client.Enabled, client.Host, client.Port, client.TLS, client.TLSSkipVerify, sealedUsername, sealedPassword, settingsJson, client.HealthCheckInterval, client.HealthCheckTimeout).
+		Suffix("RETURNING id").RunWith(tx)
 
 	// return values
 	var retID int
@@ -202,19 +319,41 @@ func (r *DownloadClientRepo) Store(ctx context.Context, client domain.DownloadCl
 
 	client.ID = retID
 
+	if err := r.recordAudit(ctx, tx, domain.AuditActionCreate, client.ID, nil, &client); err != nil {
+		return nil, errors.Wrap(err, "error recording audit log")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "error storing download client")
+	}
+
 	r.log.Debug().Msgf("download_client.store: %d", client.ID)
 
 	// save to cache
 	r.cache.Set(client.ID, &client)
 
+	r.probeAndCache(ctx, client)
+
 	return &client, nil
 }
 
 func (r *DownloadClientRepo) Update(ctx context.Context, client domain.DownloadClient) (*domain.DownloadClient, error) {
 	var err error
 
+	// always read the current row rather than trust the cache: a cache miss
+	// (e.g. right after a restart) must not silently record before=nil.
+	before, err := r.findByIDFromDB(ctx, int32(client.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading current download client for audit: %d", client.ID)
+	}
+
+	sealedUsername, sealedPassword, sealedAPIKey, err := r.sealCredentials(client.Username, client.Password, client.Settings.APIKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encrypting download client credentials")
+	}
+
 	settings := domain.DownloadClientSettings{
-		APIKey: client.Settings.APIKey,
+		APIKey: sealedAPIKey,
 		Basic:  client.Settings.Basic,
 		Rules:  client.Settings.Rules,
 	}
@@ -224,6 +363,12 @@ func (r *DownloadClientRepo) Update(ctx context.Context, client domain.DownloadC
 		return nil, errors.Wrap(err, "error marshal download client settings %+v", settings)
 	}
 
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelWriteCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	queryBuilder := r.db.squirrel.
 		Update("client").
 		Set("name", client.Name).
@@ -233,9 +378,11 @@ func (r *DownloadClientRepo) Update(ctx context.Context, client domain.DownloadC
 		Set("port", client.Port).
 		Set("tls", client.TLS).
 		Set("tls_skip_verify", client.TLSSkipVerify).
-		Set("username", client.Username).
-		Set("password", client.Password).
+		Set("username", sealedUsername).
+		Set("password", sealedPassword).
 		Set("settings", string(settingsJson)).
+		Set("health_check_interval", client.HealthCheckInterval).
+		Set("health_check_timeout", client.HealthCheckTimeout).
 		Where(sq.Eq{"id": client.ID})
 
 	query, args, err := queryBuilder.ToSql()
@@ -243,20 +390,35 @@ func (r *DownloadClientRepo) Update(ctx context.Context, client domain.DownloadC
 		return nil, errors.Wrap(err, "error building query")
 	}
 
-	_, err = r.db.handler.ExecContext(ctx, query, args...)
+	_, err = tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "error executing query")
 	}
 
+	if err := r.recordAudit(ctx, tx, domain.AuditActionUpdate, client.ID, before, &client); err != nil {
+		return nil, errors.Wrap(err, "error recording audit log")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "error updating download client")
+	}
+
 	r.log.Debug().Msgf("download_client.update: %d", client.ID)
 
 	// save to cache
 	r.cache.Set(client.ID, &client)
 
+	r.probeAndCache(ctx, client)
+
 	return &client, nil
 }
 
 func (r *DownloadClientRepo) Delete(ctx context.Context, clientID int) error {
+	before, err := r.findByIDFromDB(ctx, int32(clientID))
+	if err != nil {
+		return errors.Wrap(err, "error reading current download client for audit: %d", clientID)
+	}
+
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelWriteCommitted})
 	if err != nil {
 		return err
@@ -272,6 +434,10 @@ func (r *DownloadClientRepo) Delete(ctx context.Context, clientID int) error {
 		return errors.Wrap(err, "error deleting download client: %d", clientID)
 	}
 
+	if err := r.recordAudit(ctx, tx, domain.AuditActionDelete, clientID, before, nil); err != nil {
+		return errors.Wrap(err, "error recording audit log")
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.Wrap(err, "error deleting download client: %d", clientID)
 	}
@@ -330,3 +496,196 @@ func (r *DownloadClientRepo) deleteClientFromAction(ctx context.Context, tx *Tx,
 
 	return nil
 }
+
+// sealCredentials encrypts the fields that hold download client secrets. If
+// the repo has no sealer configured, it's a no-op so existing deployments
+// keep working until they opt in to encryption at rest.
+func (r *DownloadClientRepo) sealCredentials(username, password, apiKey string) (string, string, string, error) {
+	r.sealerMu.RLock()
+	sealer := r.sealer
+	r.sealerMu.RUnlock()
+
+	if sealer == nil {
+		return username, password, apiKey, nil
+	}
+
+	sealedUsername, err := sealer.Seal(username)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "error encrypting username")
+	}
+
+	sealedPassword, err := sealer.Seal(password)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "error encrypting password")
+	}
+
+	sealedAPIKey, err := sealer.Seal(apiKey)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "error encrypting apikey")
+	}
+
+	return sealedUsername, sealedPassword, sealedAPIKey, nil
+}
+
+// unsealClient decrypts client's credential fields in place. Values written
+// before encryption was enabled are plain text and are passed through
+// unchanged, so a fresh deployment and a migrated one behave the same.
+func (r *DownloadClientRepo) unsealClient(client *domain.DownloadClient) error {
+	r.sealerMu.RLock()
+	sealer := r.sealer
+	r.sealerMu.RUnlock()
+
+	if sealer == nil {
+		return nil
+	}
+
+	username, err := sealer.Unseal(client.Username)
+	if err != nil {
+		return errors.Wrap(err, "error decrypting username")
+	}
+	client.Username = username
+
+	password, err := sealer.Unseal(client.Password)
+	if err != nil {
+		return errors.Wrap(err, "error decrypting password")
+	}
+	client.Password = password
+
+	apiKey, err := sealer.Unseal(client.Settings.APIKey)
+	if err != nil {
+		return errors.Wrap(err, "error decrypting apikey")
+	}
+	client.Settings.APIKey = apiKey
+
+	return nil
+}
+
+// RotateKey re-encrypts every stored download client's credentials under a
+// new sealer, without ever persisting the plaintext in between. It's meant
+// to be run as a one-off admin command after provisioning a new master key.
+//
+// This deliberately doesn't route through Update: Update's audit snapshot
+// reads the current row via findByIDFromDB, which unseals with r.sealer, so
+// swapping r.sealer to newSealer before every row is rewritten would make
+// that read fail AEAD authentication against still-old-key ciphertext on
+// the very first row. Instead this re-seals each row's raw ciphertext
+// directly with crypto.RotateKey and only swaps r.sealer once every row in
+// the transaction has succeeded.
+func (r *DownloadClientRepo) RotateKey(ctx context.Context, newSealer *crypto.Sealer) error {
+	r.sealerMu.RLock()
+	oldSealer := r.sealer
+	r.sealerMu.RUnlock()
+
+	if oldSealer == nil {
+		return errors.New("cannot rotate key: no sealer currently configured")
+	}
+
+	query, args, err := r.db.squirrel.Select("id", "username", "password", "settings").From("client").ToSql()
+	if err != nil {
+		return errors.Wrap(err, "error building query")
+	}
+
+	rows, err := r.db.handler.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "error executing query")
+	}
+
+	type rawRow struct {
+		id                 int32
+		username, password string
+		settings           string
+	}
+
+	var raws []rawRow
+	for rows.Next() {
+		var row rawRow
+		if err := rows.Scan(&row.id, &row.username, &row.password, &row.settings); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "error scanning row")
+		}
+		raws = append(raws, row)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return errors.Wrap(err, "rows error")
+	}
+	rows.Close()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelWriteCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, row := range raws {
+		rotatedUsername, err := crypto.RotateKey(oldSealer, newSealer, row.username)
+		if err != nil {
+			return errors.Wrap(err, "error rotating username for download client: %d", row.id)
+		}
+
+		rotatedPassword, err := crypto.RotateKey(oldSealer, newSealer, row.password)
+		if err != nil {
+			return errors.Wrap(err, "error rotating password for download client: %d", row.id)
+		}
+
+		rotatedSettings, err := rotateSettingsAPIKey(oldSealer, newSealer, row.settings)
+		if err != nil {
+			return errors.Wrap(err, "error rotating apikey for download client: %d", row.id)
+		}
+
+		updateBuilder := r.db.squirrel.
+			Update("client").
+			Set("username", rotatedUsername).
+			Set("password", rotatedPassword).
+			Set("settings", rotatedSettings).
+			Where(sq.Eq{"id": row.id})
+
+		updateQuery, updateArgs, err := updateBuilder.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "error building query")
+		}
+
+		if _, err := tx.ExecContext(ctx, updateQuery, updateArgs...); err != nil {
+			return errors.Wrap(err, "error re-encrypting download client: %d", row.id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "error committing key rotation")
+	}
+
+	r.sealerMu.Lock()
+	r.sealer = newSealer
+	r.sealerMu.Unlock()
+
+	r.log.Info().Msgf("rotated encryption key for %d download clients", len(raws))
+
+	return nil
+}
+
+// rotateSettingsAPIKey re-seals the apikey embedded in a client's settings
+// JSON blob under newSealer via oldSealer, leaving every other settings
+// field untouched.
+func rotateSettingsAPIKey(oldSealer, newSealer *crypto.Sealer, settingsJsonStr string) (string, error) {
+	if settingsJsonStr == "" {
+		return settingsJsonStr, nil
+	}
+
+	var settings domain.DownloadClientSettings
+	if err := json.Unmarshal([]byte(settingsJsonStr), &settings); err != nil {
+		return "", errors.Wrap(err, "could not unmarshal download client settings: %v", settingsJsonStr)
+	}
+
+	rotatedAPIKey, err := crypto.RotateKey(oldSealer, newSealer, settings.APIKey)
+	if err != nil {
+		return "", errors.Wrap(err, "error rotating apikey")
+	}
+	settings.APIKey = rotatedAPIKey
+
+	out, err := json.Marshal(&settings)
+	if err != nil {
+		return "", errors.Wrap(err, "error marshal download client settings %+v", settings)
+	}
+
+	return string(out), nil
+}
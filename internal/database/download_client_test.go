@@ -0,0 +1,77 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/autobrr/autobrr/internal/crypto"
+	"github.com/autobrr/autobrr/internal/domain"
+)
+
+func TestRotateSettingsAPIKey(t *testing.T) {
+	oldSealer, err := crypto.New([]byte("old-master-key"))
+	if err != nil {
+		t.Fatalf("crypto.New() error = %v", err)
+	}
+
+	newSealer, err := crypto.New([]byte("new-master-key"))
+	if err != nil {
+		t.Fatalf("crypto.New() error = %v", err)
+	}
+
+	sealedAPIKey, err := oldSealer.Seal("hunter2-apikey")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	settings := domain.DownloadClientSettings{APIKey: sealedAPIKey}
+	settingsJson, err := json.Marshal(&settings)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	rotated, err := rotateSettingsAPIKey(oldSealer, newSealer, string(settingsJson))
+	if err != nil {
+		t.Fatalf("rotateSettingsAPIKey() error = %v", err)
+	}
+
+	var rotatedSettings domain.DownloadClientSettings
+	if err := json.Unmarshal([]byte(rotated), &rotatedSettings); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if _, err := oldSealer.Unseal(rotatedSettings.APIKey); err == nil {
+		t.Fatalf("Unseal() with old sealer succeeded on rotated apikey, want error")
+	}
+
+	got, err := newSealer.Unseal(rotatedSettings.APIKey)
+	if err != nil {
+		t.Fatalf("Unseal() with new sealer error = %v", err)
+	}
+	if got != "hunter2-apikey" {
+		t.Fatalf("Unseal() = %q, want %q", got, "hunter2-apikey")
+	}
+}
+
+func TestRotateSettingsAPIKeyEmptySettings(t *testing.T) {
+	oldSealer, err := crypto.New([]byte("old-master-key"))
+	if err != nil {
+		t.Fatalf("crypto.New() error = %v", err)
+	}
+
+	newSealer, err := crypto.New([]byte("new-master-key"))
+	if err != nil {
+		t.Fatalf("crypto.New() error = %v", err)
+	}
+
+	rotated, err := rotateSettingsAPIKey(oldSealer, newSealer, "")
+	if err != nil {
+		t.Fatalf("rotateSettingsAPIKey() error = %v", err)
+	}
+	if rotated != "" {
+		t.Fatalf("rotateSettingsAPIKey(\"\") = %q, want empty string", rotated)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/autobrr/autobrr/pkg/errors"
+)
+
+// migration is a single, ordered schema change. version must be unique and
+// monotonically increasing across the lifetime of the database.
+type migration struct {
+	version  int
+	sqlite   string
+	postgres string
+}
+
+// migrations holds every schema change in application order. Append new
+// entries here rather than editing existing ones once released.
+var migrations = []migration{
+	{
+		version: 1,
+		sqlite: `
+CREATE TABLE IF NOT EXISTS client
+(
+    id              INTEGER PRIMARY KEY,
+    name            TEXT,
+    type            TEXT,
+    enabled         BOOLEAN,
+    host            TEXT,
+    port            INTEGER,
+    tls             BOOLEAN,
+    tls_skip_verify BOOLEAN,
+    username        TEXT,
+    password        TEXT,
+    settings        TEXT
+);`,
+		postgres: `
+CREATE TABLE IF NOT EXISTS client
+(
+    id              SERIAL PRIMARY KEY,
+    name            TEXT,
+    type            TEXT,
+    enabled         BOOLEAN,
+    host            TEXT,
+    port            INTEGER,
+    tls             BOOLEAN,
+    tls_skip_verify BOOLEAN,
+    username        TEXT,
+    password        TEXT,
+    settings        TEXT
+);`,
+	},
+	{
+		version: 2,
+		sqlite: `
+CREATE TABLE IF NOT EXISTS client_pool
+(
+    id       INTEGER PRIMARY KEY,
+    name     TEXT,
+    strategy TEXT
+);
+
+CREATE TABLE IF NOT EXISTS client_pool_member
+(
+    pool_id   INTEGER NOT NULL REFERENCES client_pool(id) ON DELETE CASCADE,
+    client_id INTEGER NOT NULL REFERENCES client(id) ON DELETE CASCADE,
+    priority  INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (pool_id, client_id)
+);`,
+		postgres: `
+CREATE TABLE IF NOT EXISTS client_pool
+(
+    id       SERIAL PRIMARY KEY,
+    name     TEXT,
+    strategy TEXT
+);
+
+CREATE TABLE IF NOT EXISTS client_pool_member
+(
+    pool_id   INTEGER NOT NULL REFERENCES client_pool(id) ON DELETE CASCADE,
+    client_id INTEGER NOT NULL REFERENCES client(id) ON DELETE CASCADE,
+    priority  INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (pool_id, client_id)
+);`,
+	},
+	{
+		version: 3,
+		sqlite: `
+ALTER TABLE client ADD COLUMN health_check_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE client ADD COLUMN health_check_timeout INTEGER NOT NULL DEFAULT 0;`,
+		postgres: `
+ALTER TABLE client ADD COLUMN IF NOT EXISTS health_check_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE client ADD COLUMN IF NOT EXISTS health_check_timeout INTEGER NOT NULL DEFAULT 0;`,
+	},
+	{
+		version: 4,
+		sqlite: `
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id            INTEGER PRIMARY KEY,
+    actor_user_id INTEGER NOT NULL DEFAULT 0,
+    entity_type   TEXT NOT NULL,
+    entity_id     INTEGER NOT NULL,
+    action        TEXT NOT NULL,
+    diff          TEXT NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+		postgres: `
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id            SERIAL PRIMARY KEY,
+    actor_user_id INTEGER NOT NULL DEFAULT 0,
+    entity_type   TEXT NOT NULL,
+    entity_id     INTEGER NOT NULL,
+    action        TEXT NOT NULL,
+    diff          TEXT NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+	},
+}
+
+// migrate applies every migration with a version greater than the database's
+// current schema_migrations watermark, in order, inside its own transaction.
+func (db *DB) migrate(ctx context.Context) error {
+	if _, err := db.handler.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY);`); err != nil {
+		return errors.Wrap(err, "error creating schema_migrations table")
+	}
+
+	current := 0
+	_ = db.handler.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := db.applyMigration(ctx, m); err != nil {
+			return errors.Wrap(err, "error applying migration %d", m.version)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) applyMigration(ctx context.Context, m migration) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := m.postgres
+	if db.Driver == "sqlite" {
+		query = m.sqlite
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return errors.Wrap(err, "error executing migration sql")
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (`+placeholder(db.Driver)+`)`, m.version); err != nil {
+		return errors.Wrap(err, "error recording migration version")
+	}
+
+	return tx.Commit()
+}
+
+func placeholder(driver string) string {
+	if driver == "postgres" {
+		return "$1"
+	}
+	return "?"
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type DownloadClientRepo interface {
+	List(ctx context.Context) ([]DownloadClient, error)
+	FindByID(ctx context.Context, id int32) (*DownloadClient, error)
+	Store(ctx context.Context, client DownloadClient) (*DownloadClient, error)
+	Update(ctx context.Context, client DownloadClient) (*DownloadClient, error)
+	Delete(ctx context.Context, clientID int) error
+
+	ListPools(ctx context.Context) ([]ClientPool, error)
+	FindPoolByID(ctx context.Context, id int) (*ClientPool, error)
+	StorePool(ctx context.Context, pool ClientPool) (*ClientPool, error)
+	AddMember(ctx context.Context, poolID, clientID, priority int) error
+	RemoveMember(ctx context.Context, poolID, clientID int) error
+	DeletePool(ctx context.Context, poolID int) error
+
+	GetHealth(id int) *ClientHealth
+	ListWithHealth(ctx context.Context) ([]DownloadClientWithHealth, error)
+
+	ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, int, error)
+}
+
+// AuditAction is the kind of mutation an AuditEntry records.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "CREATE"
+	AuditActionUpdate AuditAction = "UPDATE"
+	AuditActionDelete AuditAction = "DELETE"
+)
+
+// AuditEntry is a single, immutable record of who changed what and when.
+// Diff is a JSON envelope of {"before": ..., "after": ...} with sensitive
+// fields like password and apikey redacted before it's persisted.
+type AuditEntry struct {
+	ID          int             `json:"id"`
+	ActorUserID int             `json:"actor_user_id"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    int             `json:"entity_id"`
+	Action      AuditAction     `json:"action"`
+	Diff        json.RawMessage `json:"diff"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows ListAuditEntries by entity, actor, and date range, and
+// paginates the (potentially large) result set.
+type AuditFilter struct {
+	EntityType  string
+	EntityID    int
+	ActorUserID int
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+}
+
+// ClientProber probes a download client's reachability and load using its
+// native API. Implementations live alongside the existing per-type client
+// factories so the database layer doesn't need to know about qBittorrent,
+// Deluge, etc.
+type ClientProber interface {
+	Probe(ctx context.Context, client DownloadClient) (ClientHealth, error)
+	// ActiveTorrents returns the client's current active torrent count, used
+	// by the LEAST_ACTIVE pool strategy to pick the least loaded member.
+	ActiveTorrents(ctx context.Context, client DownloadClient) (int, error)
+}
+
+// ClientHealth is the last known reachability state of a download client,
+// as observed by the periodic health-check loop.
+type ClientHealth struct {
+	ClientID  int           `json:"client_id"`
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency"`
+	Version   string        `json:"version,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// DownloadClientWithHealth pairs a client with its cached health, for
+// endpoints that want to short-circuit sends to known-down clients instead
+// of failing at push time.
+type DownloadClientWithHealth struct {
+	DownloadClient
+	Health *ClientHealth `json:"health,omitempty"`
+}
+
+// ClientPoolStrategy selects how a live member is picked for a given push.
+type ClientPoolStrategy string
+
+const (
+	ClientPoolStrategyRoundRobin   ClientPoolStrategy = "ROUND_ROBIN"
+	ClientPoolStrategyLeastActive  ClientPoolStrategy = "LEAST_ACTIVE"
+	ClientPoolStrategyFirstHealthy ClientPoolStrategy = "FIRST_HEALTHY"
+)
+
+// ClientPool is a named group of interchangeable download clients that
+// filters/actions can target instead of a single client, giving horizontal
+// scaling and failover across multiple seedboxes.
+type ClientPool struct {
+	ID       int                `json:"id"`
+	Name     string             `json:"name"`
+	Strategy ClientPoolStrategy `json:"strategy"`
+	Members  []ClientPoolMember `json:"members"`
+}
+
+// ClientPoolMember is a single download client belonging to a pool. Priority
+// orders members within a pool: lower values are preferred by the
+// FIRST_HEALTHY strategy and tried first in the ROUND_ROBIN rotation.
+type ClientPoolMember struct {
+	PoolID   int `json:"pool_id"`
+	ClientID int `json:"client_id"`
+	Priority int `json:"priority"`
+}
+
+type DownloadClientType string
+
+const (
+	DownloadClientTypeQbittorrent  DownloadClientType = "QBITTORRENT"
+	DownloadClientTypeDeluge1      DownloadClientType = "DELUGE_V1"
+	DownloadClientTypeDeluge2      DownloadClientType = "DELUGE_V2"
+	DownloadClientTypeRTorrent     DownloadClientType = "RTORRENT"
+	DownloadClientTypeTransmission DownloadClientType = "TRANSMISSION"
+	DownloadClientTypeSabnzbd      DownloadClientType = "SABNZBD"
+)
+
+type DownloadClient struct {
+	ID            int                    `json:"id"`
+	Name          string                 `json:"name"`
+	Type          DownloadClientType     `json:"type"`
+	Enabled       bool                   `json:"enabled"`
+	Host          string                 `json:"host"`
+	Port          int                    `json:"port"`
+	TLS           bool                   `json:"tls"`
+	TLSSkipVerify bool                   `json:"tls_skip_verify"`
+	Username      string                 `json:"username"`
+	Password      string                 `json:"password"`
+	Settings      DownloadClientSettings `json:"settings"`
+
+	// HealthCheckInterval and HealthCheckTimeout are in seconds; 0 falls
+	// back to the health worker's defaults.
+	HealthCheckInterval int `json:"health_check_interval,omitempty"`
+	HealthCheckTimeout  int `json:"health_check_timeout,omitempty"`
+}
+
+type DownloadClientSettings struct {
+	APIKey string              `json:"apikey,omitempty"`
+	Basic  BasicAuth           `json:"basic,omitempty"`
+	Rules  DownloadClientRules `json:"rules,omitempty"`
+}
+
+type BasicAuth struct {
+	Auth     bool   `json:"auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type DownloadClientRules struct {
+	Enabled            bool `json:"enabled,omitempty"`
+	MaxActiveDownloads int  `json:"max_active_downloads,omitempty"`
+	IgnoreSlowTorrents bool `json:"ignore_slow_torrents,omitempty"`
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2021 - 2023, Ludvig Lundgren and the autobrr contributors.
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/autobrr/autobrr/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type auditService interface {
+	ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, int, error)
+}
+
+type auditHandler struct {
+	encoder encoder
+	service auditService
+}
+
+func newAuditHandler(encoder encoder, service auditService) *auditHandler {
+	return &auditHandler{encoder: encoder, service: service}
+}
+
+func (h auditHandler) Routes(r chi.Router) {
+	r.Get("/download-clients/{clientID}/audit", h.list)
+}
+
+// list handles GET /api/download-clients/{clientID}/audit?limit=&offset=
+// returning the paginated, redacted audit trail for a single client.
+func (h auditHandler) list(w http.ResponseWriter, r *http.Request) {
+	clientID, err := strconv.Atoi(chi.URLParam(r, "clientID"))
+	if err != nil {
+		h.encoder.StatusResponse(w, http.StatusBadRequest, nil)
+		return
+	}
+
+	filter := domain.AuditFilter{
+		EntityID: clientID,
+		Limit:    50,
+	}
+
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	entries, total, err := h.service.ListAuditEntries(r.Context(), filter)
+	if err != nil {
+		h.encoder.StatusResponse(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	h.encoder.StatusResponse(w, http.StatusOK, auditListResponse{
+		Entries: entries,
+		Total:   total,
+	})
+}
+
+type auditListResponse struct {
+	Entries []domain.AuditEntry `json:"entries"`
+	Total   int                 `json:"total"`
+}